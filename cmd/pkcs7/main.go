@@ -0,0 +1,61 @@
+// Command pkcs7 provides maintenance tooling for package pkcs7.
+//
+// Usage:
+//
+//	pkcs7 vectors [-o file]
+//
+// The vectors subcommand regenerates the JSON test-vector corpus of package
+// vectors from the Go implementation. It writes to standard output, or to
+// file if -o is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/d1str0/pkcs7/vectors"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pkcs7 vectors [-o file]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "vectors":
+		runVectors(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func runVectors(args []string) {
+	fs := flag.NewFlagSet("vectors", flag.ExitOnError)
+	out := fs.String("o", "", "write the corpus to `file` instead of standard output")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		usage()
+	}
+
+	corpus, err := vectors.Generate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pkcs7:", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(corpus)
+	} else {
+		err = os.WriteFile(*out, corpus, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pkcs7:", err)
+		os.Exit(1)
+	}
+}
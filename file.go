@@ -0,0 +1,218 @@
+package pkcs7
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// PadFile pads the contents of f in place by appending PKCS#7 padding at the
+// end of the file, exactly as Pad would for the same bytes. Only the padding is
+// written, so files of any size can be padded cheaply. f must be open for
+// writing, with or without O_APPEND.
+//
+// The file is not synced, so the padding may be lost or only partly written if
+// the system crashes. Call f.Sync afterwards for durability, or use
+// PadFileAtomic to never leave a partly padded file.
+//
+// Errors from the file system are returned as they are, so unlike the padding
+// errors of this package they match neither ErrUsage nor ErrData.
+func PadFile(f *os.File, blockSize int) error {
+	if err := checkBlockSize(blockSize); err != nil {
+		return err
+	}
+
+	// Seek and Write rather than WriteAt, which fails on files opened with
+	// O_APPEND.
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(filePadding(size, blockSize))
+	return err
+}
+
+// UnpadFile removes the PKCS#7 padding from the end of f in place. The tail of
+// the file is validated with the same rules as Unpad and the file is then
+// truncated to its original length. f must be open for reading and writing.
+//
+// The file is not synced, so the truncation may be lost if the system crashes.
+// Call f.Sync afterwards for durability, or use UnpadFileAtomic.
+//
+// Errors from the file system are returned as they are and match neither
+// ErrUsage nor ErrData.
+func UnpadFile(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	padLen, err := filePadLen(f, info.Size())
+	if err != nil {
+		return err
+	}
+
+	return f.Truncate(info.Size() - int64(padLen))
+}
+
+// PadFileAtomic is like PadFile, but leaves the named file untouched if the
+// process crashes part way through. The padded contents are written to a
+// temporary file in the same directory, synced to disk and renamed over the
+// original. This copies the whole file.
+//
+// If name is a symbolic link, the file it points to is replaced and the link is
+// kept. Files that are not regular files are rejected. The new file keeps the
+// permission, setuid, setgid and sticky bits of the original. Other metadata
+// is not kept, including owner and group, timestamps, extended attributes,
+// ACLs and hard links to the original, which go on pointing to the old
+// contents.
+func PadFileAtomic(name string, blockSize int) error {
+	if err := checkBlockSize(blockSize); err != nil {
+		return err
+	}
+
+	name, f, info, err := openRegular(name)
+	if err != nil {
+		return err
+	}
+
+	return replaceFile(name, f, info, info.Size(), filePadding(info.Size(), blockSize))
+}
+
+// UnpadFileAtomic is like UnpadFile, but leaves the named file untouched if
+// the process crashes part way through. The unpadded contents are written to
+// a temporary file in the same directory, synced to disk and renamed over the
+// original. This copies the whole file. Symbolic links and metadata are
+// handled as described for PadFileAtomic.
+func UnpadFileAtomic(name string) error {
+	name, f, info, err := openRegular(name)
+	if err != nil {
+		return err
+	}
+
+	padLen, err := filePadLen(f, info.Size())
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return replaceFile(name, f, info, info.Size()-int64(padLen), nil)
+}
+
+// errNotRegular is returned by the atomic helpers for anything other than a
+// regular file, such as a directory or a device.
+var errNotRegular = errors.New("not a regular file")
+
+// openRegular resolves any symbolic links in name and opens the regular file
+// it refers to. It returns the resolved name, which is the one to replace, so
+// that a link is not itself overwritten by the rename.
+func openRegular(name string) (string, *os.File, os.FileInfo, error) {
+	resolved, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	f, err := os.Open(resolved)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return "", nil, nil, err
+	}
+
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return "", nil, nil, &os.PathError{Op: "open", Path: name, Err: errNotRegular}
+	}
+
+	return resolved, f, info, nil
+}
+
+// filePadding returns the padding Pad would append to size bytes of data.
+func filePadding(size int64, blockSize int) []byte {
+	padLen := blockSize - int(size%int64(blockSize))
+	return bytes.Repeat([]byte{byte(padLen)}, padLen)
+}
+
+// filePadLen reads the tail of f, which is size bytes long, and returns the
+// length of its padding according to Unpad.
+func filePadLen(f *os.File, size int64) (int, error) {
+	if size <= 0 {
+		return 0, ErrEmptySlice
+	}
+
+	// Padding is never longer than 255 bytes, so that's all Unpad needs to see.
+	tailLen := size
+	if tailLen > 255 {
+		tailLen = 255
+	}
+	tail := make([]byte, tailLen)
+	if _, err := f.ReadAt(tail, size-int64(len(tail))); err != nil {
+		return 0, err
+	}
+
+	data, err := Unpad(tail)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(tail) - len(data), nil
+}
+
+// replaceFile atomically replaces the named file with the first n bytes of
+// src followed by tail. The new file keeps the permission and special mode
+// bits described by info.
+// replaceFile always closes src, and does so before the rename, because
+// Windows can't rename over a file that is still open.
+func replaceFile(name string, src *os.File, info os.FileInfo, n int64, tail []byte) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		src.Close()
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	_, err = io.Copy(tmp, io.NewSectionReader(src, 0, n))
+	if closeErr := src.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(tail); err != nil {
+		return err
+	}
+	mode := info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename itself survives a crash. Not every
+	// platform supports syncing directories, so this is best effort.
+	if dir, err := os.Open(filepath.Dir(name)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package pkcs7
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile creates a file holding data in a fresh temporary directory.
+func writeTestFile(t *testing.T, data []byte) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(name, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func readTestFile(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+var fileFuncs = []struct {
+	name  string
+	pad   func(name string, blockSize int) error
+	unpad func(name string) error
+}{
+	{
+		"in place",
+		func(name string, blockSize int) error {
+			f, err := os.OpenFile(name, os.O_RDWR, 0)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return PadFile(f, blockSize)
+		},
+		func(name string) error {
+			f, err := os.OpenFile(name, os.O_RDWR, 0)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return UnpadFile(f)
+		},
+	},
+	{"atomic", PadFileAtomic, UnpadFileAtomic},
+}
+
+func TestPadFile(t *testing.T) {
+	for _, fn := range fileFuncs {
+		for i, v := range padTests {
+			if v.input == nil || v.output == nil {
+				continue
+			}
+
+			name := writeTestFile(t, v.input)
+			if err := fn.pad(name, v.blockSize); err != nil {
+				t.Fatalf("%s pad %d: %v", fn.name, i, err)
+			}
+			if o := readTestFile(t, name); !bytes.Equal(o, v.output) {
+				t.Errorf("%s pad %d: expected %x, got %x", fn.name, i, v.output, o)
+			}
+
+			if err := fn.unpad(name); err != nil {
+				t.Fatalf("%s unpad %d: %v", fn.name, i, err)
+			}
+			if o := readTestFile(t, name); !bytes.Equal(o, v.input) {
+				t.Errorf("%s unpad %d: expected %x, got %x", fn.name, i, v.input, o)
+			}
+		}
+	}
+}
+
+func TestPadFileLarge(t *testing.T) {
+	// Larger than the tail read by UnpadFile, with the maximum padding.
+	input := bytes.Repeat([]byte{0xAB}, 4*255)
+	for _, fn := range fileFuncs {
+		name := writeTestFile(t, input)
+		if err := fn.pad(name, 255); err != nil {
+			t.Fatalf("%s pad: %v", fn.name, err)
+		}
+		if o := readTestFile(t, name); len(o) != 5*255 || o[len(o)-1] != 255 {
+			t.Errorf("%s pad: unexpected padded length %d", fn.name, len(o))
+		}
+		if err := fn.unpad(name); err != nil {
+			t.Fatalf("%s unpad: %v", fn.name, err)
+		}
+		if o := readTestFile(t, name); !bytes.Equal(o, input) {
+			t.Errorf("%s unpad: contents changed", fn.name)
+		}
+	}
+}
+
+func TestPadFileAppend(t *testing.T) {
+	name := writeTestFile(t, []byte{0xDE, 0xAD, 0xBE})
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := PadFile(f, 4); err != nil {
+		t.Fatalf("pad of file opened with O_APPEND: %v", err)
+	}
+	expected := []byte{0xDE, 0xAD, 0xBE, 0x01}
+	if o := readTestFile(t, name); !bytes.Equal(o, expected) {
+		t.Errorf("expected %x, got %x", expected, o)
+	}
+}
+
+func TestPadFileErrors(t *testing.T) {
+	for _, fn := range fileFuncs {
+		name := writeTestFile(t, []byte{0x01, 0x02})
+		if err := fn.pad(name, 0); !errors.Is(err, ErrInvalidBlockSize) {
+			t.Errorf("%s pad with block size 0: unexpected error %v", fn.name, err)
+		}
+
+		name = writeTestFile(t, []byte{})
+		if err := fn.unpad(name); !errors.Is(err, ErrEmptySlice) {
+			t.Errorf("%s unpad of empty file: unexpected error %v", fn.name, err)
+		}
+
+		// Invalid padding must leave the file untouched.
+		bad := []byte{0x01, 0x02, 0x03, 0x00}
+		name = writeTestFile(t, bad)
+		if err := fn.unpad(name); !errors.Is(err, ErrInvalidPadding) {
+			t.Errorf("%s unpad of bad padding: unexpected error %v", fn.name, err)
+		}
+		if o := readTestFile(t, name); !bytes.Equal(o, bad) {
+			t.Errorf("%s unpad of bad padding modified the file: %x", fn.name, o)
+		}
+	}
+}
+
+func TestPadFileAtomicKeepsMode(t *testing.T) {
+	name := writeTestFile(t, []byte{0xDE, 0xAD})
+	if err := os.Chmod(name, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := PadFileAtomic(name, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected mode 0640, got %v", info.Mode().Perm())
+	}
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(filepath.Dir(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected 1 file in directory, found %d", len(entries))
+	}
+}
+
+func TestPadFileAtomicSymlink(t *testing.T) {
+	input := []byte{0xDE, 0xAD, 0xBE}
+	target := writeTestFile(t, input)
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if err := PadFileAtomic(link, 4); err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{0xDE, 0xAD, 0xBE, 0x01}
+	if o := readTestFile(t, target); !bytes.Equal(o, expected) {
+		t.Errorf("pad through link: expected target %x, got %x", expected, o)
+	}
+
+	if err := UnpadFileAtomic(link); err != nil {
+		t.Fatal(err)
+	}
+	if o := readTestFile(t, target); !bytes.Equal(o, input) {
+		t.Errorf("unpad through link: expected target %x, got %x", input, o)
+	}
+
+	// The link itself must survive.
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link was replaced by a file with mode %v", info.Mode())
+	}
+}
+
+func TestPadFileAtomicNotRegular(t *testing.T) {
+	dir := t.TempDir()
+	if err := PadFileAtomic(dir, 4); err == nil {
+		t.Error("pad of a directory succeeded")
+	}
+	if err := UnpadFileAtomic(dir); err == nil {
+		t.Error("unpad of a directory succeeded")
+	}
+}
+
+func TestPadFileIOErrors(t *testing.T) {
+	// File system errors are returned as they are and belong to no class.
+	missing := filepath.Join(t.TempDir(), "missing")
+	for _, fn := range fileFuncs {
+		err := fn.unpad(missing)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s unpad of missing file: unexpected error %v", fn.name, err)
+		}
+		if errors.Is(err, ErrUsage) || errors.Is(err, ErrData) {
+			t.Errorf("%s unpad of missing file: %v matches an error class", fn.name, err)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/d1str0/pkcs7
+
+go 1.23
@@ -0,0 +1,83 @@
+package pkcs7
+
+import "iter"
+
+// Blocks returns an iterator over the blocks of src padded to blockSize, as if
+// Pad had been called, without copying src. Every block but the last is a
+// subslice of src. Only the final block, holding the padding, is allocated.
+//
+// Example Input: Block Size 4, Source {0xDE, 0xAD, 0xBE, 0xEF, 0x01}
+//
+// Expected Output: {0xDE, 0xAD, 0xBE, 0xEF}, {0x01, 0x03, 0x03, 0x03}
+func Blocks(src []byte, blockSize int) (iter.Seq[[]byte], error) {
+	if err := checkBlockSize(blockSize); err != nil {
+		return nil, err
+	}
+
+	// Length of the part of src made up of whole blocks.
+	whole := len(src) - len(src)%blockSize
+
+	return func(yield func([]byte) bool) {
+		for i := 0; i < whole; i += blockSize {
+			// Cap the block so appending to it can't overwrite src.
+			if !yield(src[i : i+blockSize : i+blockSize]) {
+				return
+			}
+		}
+
+		// Copy the remainder of src into a block of its own and pad it there.
+		last := make([]byte, 0, blockSize)
+		last, _ = Pad(append(last, src[whole:]...), blockSize)
+		yield(last)
+	}, nil
+}
+
+// UnpadBlocks returns an iterator over the data held in blocks, such as the
+// blocks produced by Blocks. Every block must be exactly blockSize bytes long,
+// and the final block must end in PKCS#7 padding. The padding is removed from
+// the final block according to PadLen, so padding longer than blockSize is
+// rejected. Any error, including ErrInvalidBlockSize and ErrInvalidLength for
+// a block of the wrong length, is yielded as the final element.
+//
+// Each block is held back until the next one arrives, because only then is it
+// known not to be the last. It is copied, so blocks may reuse their buffers.
+// The yielded data is only valid until the next iteration.
+func UnpadBlocks(blocks iter.Seq[[]byte], blockSize int) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		if err := checkBlockSize(blockSize); err != nil {
+			yield(nil, err)
+			return
+		}
+
+		held := make([]byte, 0, blockSize)
+		seen := false
+
+		for block := range blocks {
+			if len(block) != blockSize {
+				yield(nil, ErrInvalidLength)
+				return
+			}
+			if seen && !yield(held, nil) {
+				return
+			}
+			held = append(held[:0], block...)
+			seen = true
+		}
+
+		if !seen {
+			yield(nil, ErrEmptySlice)
+			return
+		}
+
+		padLen, err := PadLen(held, blockSize)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		// A block made up entirely of padding holds no data.
+		if padLen < blockSize {
+			yield(held[:blockSize-padLen], nil)
+		}
+	}
+}
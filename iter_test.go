@@ -0,0 +1,128 @@
+package pkcs7
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestBlocks(t *testing.T) {
+	for i, v := range padTests {
+		if v.input == nil {
+			continue
+		}
+
+		// Give src spare capacity to check it is never written to.
+		src := append(make([]byte, 0, len(v.input)+v.blockSize), v.input...)
+		spare := src[len(src):cap(src)]
+
+		blocks, err := Blocks(src, v.blockSize)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidBlockSize) || v.errorString == "" {
+				t.Errorf("Blocks %d: unexpected error %v", i, err)
+			}
+			continue
+		}
+
+		var o []byte
+		for block := range blocks {
+			if len(block) != v.blockSize {
+				t.Errorf("Blocks %d: expected block of %d bytes, got %d", i, v.blockSize, len(block))
+			}
+			o = append(o, block...)
+		}
+		if !bytes.Equal(o, v.output) {
+			t.Errorf("Blocks %d: expected %x, got %x", i, v.output, o)
+		}
+		if !bytes.Equal(spare, make([]byte, len(spare))) {
+			t.Errorf("Blocks %d: wrote past the end of src", i)
+		}
+	}
+}
+
+func TestUnpadBlocks(t *testing.T) {
+	for i, v := range padTests {
+		if v.input == nil || v.output == nil {
+			continue
+		}
+
+		blocks, err := Blocks(v.input, v.blockSize)
+		if err != nil {
+			t.Fatalf("Blocks %d: %v", i, err)
+		}
+
+		var o []byte
+		for data, err := range UnpadBlocks(blocks, v.blockSize) {
+			if err != nil {
+				t.Fatalf("UnpadBlocks %d: %v", i, err)
+			}
+			o = append(o, data...)
+		}
+		if !bytes.Equal(o, v.input) {
+			t.Errorf("UnpadBlocks %d: expected %x, got %x", i, v.input, o)
+		}
+	}
+}
+
+func TestUnpadBlocksErrors(t *testing.T) {
+	tests := []struct {
+		blockSize int
+		blocks    [][]byte
+		data      []byte
+		err       error
+	}{
+		// No blocks at all.
+		{4, nil, nil, ErrEmptySlice},
+		// Final zero byte.
+		{4, [][]byte{{0x01, 0x02, 0x03, 0x00}}, nil, ErrInvalidPadding},
+		// Bad padding after a good block, which is still yielded.
+		{4, [][]byte{{0x04, 0x04, 0x04, 0x04}, {0x01, 0x02, 0x03, 0x05}}, []byte{0x04, 0x04, 0x04, 0x04}, ErrInvalidPadding},
+		// Short block.
+		{4, [][]byte{{0x01, 0x02, 0x01}}, nil, ErrInvalidLength},
+		// Over-long block.
+		{4, [][]byte{{0x01, 0x02, 0x03, 0x04, 0x01}}, nil, ErrInvalidLength},
+		// Validly padded data split at the wrong place yields none of it.
+		{4, [][]byte{{0x01, 0x02, 0x04, 0x04}, {0x04, 0x04}}, nil, ErrInvalidLength},
+		// A single block of padding longer than the block size.
+		{8, [][]byte{bytes.Repeat([]byte{0x09}, 9)}, nil, ErrInvalidLength},
+		// Padding longer than the block size, in blocks of the right length.
+		{4, [][]byte{{0x05, 0x05, 0x05, 0x05}, {0x05, 0x05, 0x05, 0x05}}, []byte{0x05, 0x05, 0x05, 0x05}, ErrInvalidPadding},
+		// Unsupported block sizes.
+		{0, [][]byte{{0x01}}, nil, ErrInvalidBlockSize},
+		{256, [][]byte{{0x01}}, nil, ErrInvalidBlockSize},
+	}
+
+	for i, v := range tests {
+		var data []byte
+		var last error
+		for o, err := range UnpadBlocks(slices.Values(v.blocks), v.blockSize) {
+			data = append(data, o...)
+			last = err
+		}
+		if !errors.Is(last, v.err) {
+			t.Errorf("UnpadBlocks %d: expected error %v, got %v", i, v.err, last)
+		}
+		if !bytes.Equal(data, v.data) {
+			t.Errorf("UnpadBlocks %d: expected data %x, got %x", i, v.data, data)
+		}
+	}
+}
+
+func TestUnpadBlocksStop(t *testing.T) {
+	blocks, err := Blocks(bytes.Repeat([]byte{0xAB}, 64), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for range UnpadBlocks(blocks, 16) {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("expected to stop after 2 blocks, got %d", n)
+	}
+}
@@ -0,0 +1,111 @@
+package pkcs7
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Mutation is a malformed variant of a validly padded buffer, labelled with
+// the error PadLen returns for it. Mutations are meant for table-driven tests
+// of code built on top of this package.
+type Mutation struct {
+	// Name describes the change made, such as "wrong padding byte at 13".
+	Name string
+
+	// Data is the malformed buffer. Each mutation has its own copy.
+	Data []byte
+
+	// Err is the error PadLen(Data, blockSize) returns.
+	Err error
+}
+
+// Mutations returns an iterator over malformed variants of padded, which must
+// be a whole number of blocks of blockSize bytes ending in valid padding. An
+// error is returned if it is not. The variants are:
+//
+//   - a final padding byte of zero
+//   - a final padding byte larger than blockSize
+//   - a final padding byte larger than padded
+//   - one wrong byte at each position of the padding before the final byte
+//   - padded truncated by 1 to blockSize-1 bytes, and to nothing
+//   - padded followed by 1 to blockSize-1 extra bytes, and by an extra block
+//
+// Variants that can't be expressed for the given sizes, such as a padding byte
+// above 255, are skipped.
+func Mutations(padded []byte, blockSize int) (iter.Seq[Mutation], error) {
+	padLen, err := PadLen(padded, blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	length := len(padded)
+
+	return func(yield func(Mutation) bool) {
+		// withLast returns padded with its final byte replaced by b.
+		withLast := func(b int) []byte {
+			data := append([]byte(nil), padded...)
+			data[length-1] = byte(b)
+			return data
+		}
+
+		if !yield(Mutation{"zero padding byte", withLast(0), ErrInvalidPadding}) {
+			return
+		}
+
+		if blockSize < 255 {
+			m := Mutation{"padding longer than block size", withLast(blockSize + 1), ErrInvalidPadding}
+			if !yield(m) {
+				return
+			}
+		}
+
+		// When padded is a single block this is the same as the previous case.
+		if length < 255 && length > blockSize {
+			m := Mutation{"padding longer than input", withLast(length + 1), ErrInvalidPadding}
+			if !yield(m) {
+				return
+			}
+		}
+
+		for i := length - padLen; i < length-1; i++ {
+			data := append([]byte(nil), padded...)
+			data[i] ^= 0xFF
+			m := Mutation{fmt.Sprintf("wrong padding byte at %d", i), data, ErrInvalidPadding}
+			if !yield(m) {
+				return
+			}
+		}
+
+		for n := 1; n < blockSize; n++ {
+			data := append([]byte(nil), padded[:length-n]...)
+			m := Mutation{fmt.Sprintf("truncated by %d %s", n, bytesNoun(n)), data, ErrInvalidLength}
+			if !yield(m) {
+				return
+			}
+		}
+
+		if !yield(Mutation{"truncated to nothing", []byte{}, ErrEmptySlice}) {
+			return
+		}
+
+		for n := 1; n < blockSize; n++ {
+			data := append(append([]byte(nil), padded...), make([]byte, n)...)
+			m := Mutation{fmt.Sprintf("%d extra trailing %s", n, bytesNoun(n)), data, ErrInvalidLength}
+			if !yield(m) {
+				return
+			}
+		}
+
+		// A zeroed extra block ends in a zero padding byte.
+		data := append(append([]byte(nil), padded...), make([]byte, blockSize)...)
+		yield(Mutation{"extra trailing block", data, ErrInvalidPadding})
+	}, nil
+}
+
+// bytesNoun returns "byte" or "bytes" to follow the count n in a name.
+func bytesNoun(n int) string {
+	if n == 1 {
+		return "byte"
+	}
+	return "bytes"
+}
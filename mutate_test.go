@@ -0,0 +1,133 @@
+package pkcs7
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMutations(t *testing.T) {
+	inputs := []struct {
+		blockSize int
+		input     []byte
+	}{
+		{1, []byte{}},
+		{1, []byte{0xDE, 0xAD}},
+		{4, []byte{0xDE, 0xAD, 0xBE}},
+		{8, []byte{}},
+		{16, bytes.Repeat([]byte{0xAB}, 37)},
+		{255, bytes.Repeat([]byte{0xAB}, 300)},
+	}
+
+	for _, v := range inputs {
+		padded, err := Pad(v.input, v.blockSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig := append([]byte(nil), padded...)
+
+		mutations, err := Mutations(padded, v.blockSize)
+		if err != nil {
+			t.Fatalf("Mutations %x: %v", padded, err)
+		}
+
+		names := make(map[string]bool)
+		for m := range mutations {
+			if names[m.Name] {
+				t.Errorf("block size %d: duplicate mutation %q", v.blockSize, m.Name)
+			}
+			names[m.Name] = true
+
+			if _, err := PadLen(m.Data, v.blockSize); !errors.Is(err, m.Err) {
+				t.Errorf("block size %d, %s: expected %v, got %v", v.blockSize, m.Name, m.Err, err)
+			}
+			if Valid(m.Data, v.blockSize) || ValidCT(m.Data, v.blockSize) != 0 {
+				t.Errorf("block size %d, %s: reported as valid", v.blockSize, m.Name)
+			}
+		}
+
+		if len(names) == 0 {
+			t.Errorf("block size %d: no mutations", v.blockSize)
+		}
+		if !bytes.Equal(padded, orig) {
+			t.Errorf("block size %d: Mutations modified its input", v.blockSize)
+		}
+	}
+}
+
+// TestMutationsNames checks the exact list of mutations for fixed inputs, so
+// that dropping a class of mutation can't go unnoticed.
+func TestMutationsNames(t *testing.T) {
+	tests := []struct {
+		blockSize int
+		input     []byte
+		names     []string
+	}{
+		// A single block with one byte of padding.
+		{
+			4,
+			[]byte{0xDE, 0xAD, 0xBE},
+			[]string{
+				"zero padding byte",
+				"padding longer than block size",
+				"truncated by 1 byte",
+				"truncated by 2 bytes",
+				"truncated by 3 bytes",
+				"truncated to nothing",
+				"1 extra trailing byte",
+				"2 extra trailing bytes",
+				"3 extra trailing bytes",
+				"extra trailing block",
+			},
+		},
+		// Two blocks with three bytes of padding.
+		{
+			4,
+			[]byte{0xDE, 0xAD, 0xBE, 0xEF, 0xDE},
+			[]string{
+				"zero padding byte",
+				"padding longer than block size",
+				"padding longer than input",
+				"wrong padding byte at 5",
+				"wrong padding byte at 6",
+				"truncated by 1 byte",
+				"truncated by 2 bytes",
+				"truncated by 3 bytes",
+				"truncated to nothing",
+				"1 extra trailing byte",
+				"2 extra trailing bytes",
+				"3 extra trailing bytes",
+				"extra trailing block",
+			},
+		},
+	}
+
+	for i, v := range tests {
+		padded, err := Pad(v.input, v.blockSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mutations, err := Mutations(padded, v.blockSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for m := range mutations {
+			names = append(names, m.Name)
+		}
+		if !slices.Equal(names, v.names) {
+			t.Errorf("Mutations %d: expected %q, got %q", i, v.names, names)
+		}
+	}
+}
+
+func TestMutationsInvalidInput(t *testing.T) {
+	if _, err := Mutations([]byte{0x01, 0x02, 0x03, 0x00}, 4); !errors.Is(err, ErrInvalidPadding) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := Mutations([]byte{0x01}, 0); !errors.Is(err, ErrInvalidBlockSize) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
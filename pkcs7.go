@@ -13,15 +13,50 @@ package pkcs7
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"errors"
 )
 
+// Errors are split into two classes so callers can tell a programming mistake
+// apart from bad input. Every padding error returned by this package matches
+// exactly one of ErrUsage or ErrData with errors.Is. File system errors from
+// the file helpers, such as PadFile, are returned as they are and match
+// neither.
 var (
-	ErrInvalidBlockSize = errors.New("pkcs7: block size must be between 1 and 255 inclusive")
-	ErrEmptySlice       = errors.New("pkcs7: source must not be empty slice")
-	ErrInvalidPadding   = errors.New("pkcs7: invalid padding")
+	// ErrUsage is matched by errors caused by invalid arguments, such as an
+	// unsupported block size.
+	ErrUsage = errors.New("pkcs7: usage error")
+
+	// ErrData is matched by errors caused by the data being processed, such as
+	// truncated input or malformed padding.
+	ErrData = errors.New("pkcs7: data error")
+)
+
+// ErrInvalidBlockSize is a usage error. ErrEmptySlice, ErrInvalidPadding and
+// ErrInvalidLength are data errors: empty or truncated input, malformed
+// padding, and input that is not a whole number of blocks.
+var (
+	ErrInvalidBlockSize = newError(ErrUsage, "pkcs7: block size must be between 1 and 255 inclusive")
+	ErrEmptySlice       = newError(ErrData, "pkcs7: source must not be empty slice")
+	ErrInvalidPadding   = newError(ErrData, "pkcs7: invalid padding")
+	ErrInvalidLength    = newError(ErrData, "pkcs7: source length must be a multiple of the block size")
 )
 
+// pkcs7Error is a sentinel error belonging to one of the error classes.
+type pkcs7Error struct {
+	class error
+	msg   string
+}
+
+func newError(class error, msg string) error {
+	return &pkcs7Error{class: class, msg: msg}
+}
+
+func (e *pkcs7Error) Error() string { return e.msg }
+
+// Unwrap returns the class of the error, ErrUsage or ErrData.
+func (e *pkcs7Error) Unwrap() error { return e.class }
+
 // Pad takes a source byte slice and a block size. It will determine the needed
 // amount of padding, n, and appends byte(n) to the source n times.
 //
@@ -30,9 +65,8 @@ var (
 // Expected Output: {0xDE, 0xAD, 0xBE, 0xEF, 0x04, 0x04, 0x04, 0x04}
 //
 func Pad(src []byte, blockSize int) ([]byte, error) {
-	// Only allow 1-255 sized blocks as per standard.
-	if blockSize < 1 || blockSize > 255 {
-		return nil, ErrInvalidBlockSize
+	if err := checkBlockSize(blockSize); err != nil {
+		return nil, err
 	}
 
 	// Calculate length of needed padding by taking the goal block size and
@@ -59,34 +93,103 @@ func Unpad(src []byte) ([]byte, error) {
 		return nil, ErrEmptySlice
 	}
 
-	// Get the last byte so we know how many bytes to take off the end.
-	padLen := int(src[length-1])
+	// Without a block size the padding may be as long as the whole source, up
+	// to the largest value a single byte can hold.
+	maxLen := length
+	if maxLen > 255 {
+		maxLen = 255
+	}
+	padLen, good := checkPadding(src, maxLen)
 
-	// If the last byte is 0x00, we have invalid padding. We try to fuzz a bit
-	// the error message, sending the same one as when the padding is incorrect.
-	if padLen == 0x00 {
+	// Zero padding, padding longer than the source and mismatched padding
+	// bytes all produce the same error so they can't be told apart.
+	if good != 1 {
 		return nil, ErrInvalidPadding
 	}
 
-	// If the last byte is more than the total length, this is invalid.
-	if padLen > length {
-		return nil, ErrInvalidPadding
+	// Return the source bytes up to the start of the padding.
+	return src[:length-padLen], nil
+}
+
+// PadLen reports the length of the padding at the end of src, which must be a
+// whole number of blocks of blockSize bytes. Unlike Unpad, padding longer than
+// blockSize is rejected. An error is returned for invalid padding.
+func PadLen(src []byte, blockSize int) (int, error) {
+	if err := checkBlockSize(blockSize); err != nil {
+		return 0, err
 	}
 
-	// Get original source length assumed based on last byte.
-	origLen := length - padLen
+	if len(src) == 0 {
+		return 0, ErrEmptySlice
+	}
 
-	// Get all the padding so we can check it's actually padding and not just an
-	// invalid last byte.
-	padding := src[origLen:]
+	// Padded data always ends on a block boundary.
+	if len(src)%blockSize != 0 {
+		return 0, ErrInvalidLength
+	}
 
-	for i := 0; i < padLen; i++ {
-		// Make sure all bytes match.
-		if padding[i] != byte(padLen) {
-			return nil, ErrInvalidPadding
-		}
+	padLen, good := checkPadding(src, blockSize)
+	if good != 1 {
+		return 0, ErrInvalidPadding
 	}
 
-	// Return the source bytes up to the start of the padding.
-	return src[:origLen], nil
+	return padLen, nil
+}
+
+// Valid reports whether src is a whole number of blocks of blockSize bytes
+// ending in valid padding, as checked by PadLen.
+func Valid(src []byte, blockSize int) bool {
+	_, err := PadLen(src, blockSize)
+	return err == nil
+}
+
+// ValidCT is like Valid, but returns a mask: -1, with all bits set, if the
+// padding is valid and 0 otherwise. The mask can be combined with & to select
+// values without branching. Its running time depends only on len(src) and
+// blockSize, never on the contents of src, so it can be used on decrypted data
+// without leaking whether the padding was correct through timing.
+func ValidCT(src []byte, blockSize int) int {
+	// The lengths are public, so branching on them leaks nothing.
+	if checkBlockSize(blockSize) != nil || len(src) == 0 || len(src)%blockSize != 0 {
+		return 0
+	}
+
+	// Turn 1 into all ones and leave 0 as it is.
+	_, good := checkPadding(src, blockSize)
+	return -good
+}
+
+// checkBlockSize returns ErrInvalidBlockSize unless blockSize is between 1 and
+// 255 inclusive.
+func checkBlockSize(blockSize int) error {
+	// Only allow 1-255 sized blocks as per standard.
+	if blockSize < 1 || blockSize > 255 {
+		return ErrInvalidBlockSize
+	}
+	return nil
+}
+
+// checkPadding is the validation core shared by Unpad, PadLen and ValidCT. It
+// returns the padding length claimed by the last byte of src, and good set to 1
+// if that padding is valid and no longer than maxLen, or 0 otherwise. The last
+// maxLen bytes of src are always read and no branch depends on their values.
+// The caller must ensure that 0 < maxLen <= min(len(src), 255).
+func checkPadding(src []byte, maxLen int) (padLen, good int) {
+	length := len(src)
+
+	// Get the last byte so we know how many bytes to take off the end.
+	padLen = int(src[length-1])
+
+	// The padding must be at least one byte and fit within maxLen.
+	good = subtle.ConstantTimeLessOrEq(1, padLen) & subtle.ConstantTimeLessOrEq(padLen, maxLen)
+
+	// Every byte within the padding must hold the padding length. Bytes before
+	// the padding are inspected as well and their result discarded.
+	for i := 1; i <= maxLen; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, padLen)
+		matches := subtle.ConstantTimeByteEq(src[length-i], byte(padLen))
+		good &= matches | (inPadding ^ 1)
+	}
+
+	return padLen, good
 }
@@ -2,6 +2,7 @@ package pkcs7
 
 import (
 	"bytes"
+	"errors"
 	"strings"
 	"testing"
 )
@@ -119,3 +120,123 @@ func TestUnpad(t *testing.T) {
 		}
 	}
 }
+
+var errorClassTests = []struct {
+	err   error
+	class error
+	other error
+}{
+	{ErrInvalidBlockSize, ErrUsage, ErrData},
+	{ErrEmptySlice, ErrData, ErrUsage},
+	{ErrInvalidPadding, ErrData, ErrUsage},
+	{ErrInvalidLength, ErrData, ErrUsage},
+}
+
+func TestErrorClasses(t *testing.T) {
+	for _, v := range errorClassTests {
+		if !errors.Is(v.err, v.err) {
+			t.Errorf("%v does not match itself", v.err)
+		}
+		if !errors.Is(v.err, v.class) {
+			t.Errorf("%v does not match %v", v.err, v.class)
+		}
+		if errors.Is(v.err, v.other) {
+			t.Errorf("%v unexpectedly matches %v", v.err, v.other)
+		}
+	}
+
+	// Errors returned from the API match both their sentinel and their class.
+	if _, err := Pad([]byte{0x01}, 0); !errors.Is(err, ErrInvalidBlockSize) || !errors.Is(err, ErrUsage) {
+		t.Errorf("Pad with block size 0: unexpected error %v", err)
+	}
+	if _, err := Unpad(nil); !errors.Is(err, ErrEmptySlice) || !errors.Is(err, ErrData) {
+		t.Errorf("Unpad of empty slice: unexpected error %v", err)
+	}
+	if _, err := Unpad([]byte{0x01, 0x02, 0x03, 0x00}); !errors.Is(err, ErrInvalidPadding) || !errors.Is(err, ErrData) {
+		t.Errorf("Unpad of bad padding: unexpected error %v", err)
+	}
+}
+
+var padLenTests = []struct {
+	blockSize int
+	input     []byte
+	padLen    int
+	err       error
+}{
+	// Valid padding of every length within a block.
+	{4, []byte{0xDE, 0xAD, 0xBE, 0x01}, 1, nil},
+	{4, []byte{0xDE, 0xAD, 0x02, 0x02}, 2, nil},
+	{4, []byte{0xDE, 0x03, 0x03, 0x03}, 3, nil},
+	{4, []byte{0x04, 0x04, 0x04, 0x04}, 4, nil},
+	{4, []byte{0xDE, 0xAD, 0xBE, 0xEF, 0x04, 0x04, 0x04, 0x04}, 4, nil},
+	{1, []byte{0x01}, 1, nil},
+	// Final zero byte.
+	{4, []byte{0x01, 0x02, 0x03, 0x00}, 0, ErrInvalidPadding},
+	// Padding longer than the block size, which Unpad alone would accept.
+	{4, []byte{0x05, 0x05, 0x05, 0x05, 0x05, 0x05, 0x05, 0x05}, 0, ErrInvalidPadding},
+	// Padding bytes that do not all match.
+	{4, []byte{0xDE, 0x03, 0x02, 0x03}, 0, ErrInvalidPadding},
+	{4, []byte{0x04, 0x04, 0x05, 0x04}, 0, ErrInvalidPadding},
+	// Source not a whole number of blocks.
+	{4, []byte{0xDE, 0xAD, 0x01}, 0, ErrInvalidLength},
+	// Empty source.
+	{4, []byte{}, 0, ErrEmptySlice},
+	// Unsupported block sizes.
+	{0, []byte{0x01}, 0, ErrInvalidBlockSize},
+	{256, []byte{0x01}, 0, ErrInvalidBlockSize},
+}
+
+func TestPadLen(t *testing.T) {
+	for i, v := range padLenTests {
+		n, err := PadLen(v.input, v.blockSize)
+		if !errors.Is(err, v.err) {
+			t.Errorf("PadLen %d: expected error %v, got %v", i, v.err, err)
+		}
+		if n != v.padLen {
+			t.Errorf("PadLen %d: expected %d, got %d", i, v.padLen, n)
+		}
+
+		if valid := Valid(v.input, v.blockSize); valid != (v.err == nil) {
+			t.Errorf("Valid %d: expected %t, got %t", i, v.err == nil, valid)
+		}
+
+		want := 0
+		if v.err == nil {
+			want = -1
+		}
+		if got := ValidCT(v.input, v.blockSize); got != want {
+			t.Errorf("ValidCT %d: expected %d, got %d", i, want, got)
+		}
+
+		// The mask selects a value without branching.
+		if got := 0x5A & ValidCT(v.input, v.blockSize); got != 0x5A&want {
+			t.Errorf("ValidCT %d: mask selected %#x", i, got)
+		}
+	}
+}
+
+// TestPadLenMatchesUnpad checks every possible final block against Unpad.
+func TestPadLenMatchesUnpad(t *testing.T) {
+	const blockSize = 8
+	block := make([]byte, blockSize)
+	for last := 0; last < 256; last++ {
+		for fill := 0; fill < 256; fill++ {
+			for i := range block {
+				block[i] = byte(fill)
+			}
+			block[blockSize-1] = byte(last)
+
+			o, unpadErr := Unpad(block)
+			n, err := PadLen(block, blockSize)
+			if unpadErr != nil {
+				if err == nil {
+					t.Fatalf("PadLen accepted %x rejected by Unpad", block)
+				}
+				continue
+			}
+			if err == nil && len(o) != blockSize-n {
+				t.Fatalf("PadLen %x: got %d, Unpad removed %d", block, n, blockSize-len(o))
+			}
+		}
+	}
+}
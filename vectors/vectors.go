@@ -0,0 +1,224 @@
+// Package vectors holds a language-neutral corpus of PKCS#7 padding test
+// vectors, generated from package pkcs7 and embedded in the binary. It lets
+// implementations in other languages check that they pad and unpad exactly as
+// this package does.
+//
+// The corpus is a JSON document. All byte strings are lowercase hex. Every
+// valid vector must satisfy both Pad(data, block_size) == padded and
+// Unpad(padded) == data. Every invalid vector must fail with the named error
+// when op is applied to input with block_size. The document's ops field
+// describes each op. In particular, "unpad_blocks" checks padding against the
+// block size, as pkcs7.PadLen does, and is stricter than pkcs7.Unpad alone.
+//
+// To regenerate pkcs7.json after changing package pkcs7, run go generate.
+package vectors
+
+//go:generate go run ../cmd/pkcs7 vectors -o pkcs7.json
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/d1str0/pkcs7"
+)
+
+// Version is the version of the corpus format. It changes whenever the layout
+// of the JSON document changes in a way readers need to know about.
+const Version = 1
+
+// Error names used in the corpus.
+const (
+	NameInvalidBlockSize = "invalid_block_size"
+	NameEmptySlice       = "empty_slice"
+	NameInvalidLength    = "invalid_length"
+	NameInvalidPadding   = "invalid_padding"
+)
+
+// Operations used by invalid vectors.
+const (
+	// OpPad pads input to block_size, as pkcs7.Pad does.
+	OpPad = "pad"
+
+	// OpUnpadBlocks removes the padding from input, which must be a whole
+	// number of blocks of block_size bytes whose padding is no longer than
+	// block_size, as pkcs7.PadLen checks.
+	OpUnpadBlocks = "unpad_blocks"
+)
+
+// ops describes each operation in the JSON document for readers that don't
+// have this package's documentation.
+var ops = map[string]string{
+	OpPad: "Pad input to a multiple of block_size. Fails if block_size is not " +
+		"between 1 and 255 inclusive.",
+	OpUnpadBlocks: "Remove padding from input using block_size. Fails if " +
+		"block_size is not between 1 and 255 inclusive, input is empty, input " +
+		"is not a multiple of block_size, or the padding is zero, longer than " +
+		"block_size or not made of identical bytes. This is stricter than an " +
+		"unpad that does not know the block size.",
+}
+
+// Corpus is the embedded JSON corpus, as written by Generate.
+//
+//go:embed pkcs7.json
+var Corpus []byte
+
+// File is the top-level JSON document.
+type File struct {
+	Version int               `json:"version"`
+	Scheme  string            `json:"scheme"`
+	Ops     map[string]string `json:"ops"`
+	Valid   []Valid           `json:"valid"`
+	Invalid []Invalid         `json:"invalid"`
+}
+
+// Valid is a vector that pads and unpads successfully.
+type Valid struct {
+	BlockSize int    `json:"block_size"`
+	Data      string `json:"data"`
+	Padded    string `json:"padded"`
+}
+
+// Invalid is a vector for which op, either OpPad or OpUnpadBlocks, must fail.
+type Invalid struct {
+	Comment   string `json:"comment"`
+	Op        string `json:"op"`
+	BlockSize int    `json:"block_size"`
+	Input     string `json:"input"`
+	Error     string `json:"error"`
+}
+
+// Load parses the embedded corpus.
+func Load() (*File, error) {
+	var f File
+	if err := json.Unmarshal(Corpus, &f); err != nil {
+		return nil, err
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("vectors: unsupported corpus version %d", f.Version)
+	}
+	return &f, nil
+}
+
+// Generate builds the corpus from package pkcs7 and returns it encoded as
+// JSON. The output is deterministic, so it can be compared with Corpus.
+func Generate() ([]byte, error) {
+	f := &File{Version: Version, Scheme: "pkcs7", Ops: ops}
+
+	// Edge lengths for every block size: empty, a single byte, one short of a
+	// block, a whole block and one past a block.
+	for blockSize := 1; blockSize <= 255; blockSize++ {
+		lengths := []int{0, 1, blockSize - 1, blockSize, blockSize + 1}
+		slices.Sort(lengths)
+		for _, n := range slices.Compact(lengths) {
+			data := pattern(n)
+			padded, err := pkcs7.Pad(data, blockSize)
+			if err != nil {
+				return nil, err
+			}
+			f.Valid = append(f.Valid, Valid{
+				BlockSize: blockSize,
+				Data:      hex.EncodeToString(data),
+				Padded:    hex.EncodeToString(padded),
+			})
+		}
+	}
+
+	for _, v := range invalidInputs() {
+		var err error
+		if v.Op == OpPad {
+			_, err = pkcs7.Pad(v.input, v.BlockSize)
+		} else {
+			_, err = pkcs7.PadLen(v.input, v.BlockSize)
+		}
+		if err == nil {
+			return nil, fmt.Errorf("vectors: %s vector %q did not fail", v.Op, v.Comment)
+		}
+
+		v.Input = hex.EncodeToString(v.input)
+		v.Error = errorName(err)
+		f.Invalid = append(f.Invalid, v.Invalid)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type invalidInput struct {
+	Invalid
+	input []byte
+}
+
+// invalidInputs returns the invalid cases, with Error still to be filled in.
+func invalidInputs() []invalidInput {
+	var vs []invalidInput
+	add := func(comment, op string, blockSize int, input []byte) {
+		vs = append(vs, invalidInput{
+			Invalid: Invalid{Comment: comment, Op: op, BlockSize: blockSize},
+			input:   input,
+		})
+	}
+
+	for _, blockSize := range []int{0, 256} {
+		add("unsupported block size", OpPad, blockSize, pattern(4))
+		add("unsupported block size", OpUnpadBlocks, blockSize, []byte{0x01})
+	}
+
+	for _, blockSize := range []int{1, 2, 8, 16, 255} {
+		add("empty input", OpUnpadBlocks, blockSize, []byte{})
+
+		block := bytes.Repeat([]byte{byte(blockSize)}, blockSize)
+
+		zero := bytes.Clone(block)
+		zero[blockSize-1] = 0x00
+		add("zero padding byte", OpUnpadBlocks, blockSize, zero)
+
+		if blockSize < 255 {
+			long := bytes.Repeat([]byte{byte(blockSize + 1)}, 2*blockSize)
+			add("padding longer than block size", OpUnpadBlocks, blockSize, long)
+		}
+
+		if blockSize > 1 {
+			add("input not a multiple of block size", OpUnpadBlocks, blockSize, block[1:])
+
+			mismatch := bytes.Clone(block)
+			mismatch[0] ^= 0xFF
+			add("first padding byte mismatched", OpUnpadBlocks, blockSize, mismatch)
+		}
+	}
+
+	return vs
+}
+
+// pattern returns n bytes of deterministic test data.
+func pattern(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+// errorName maps an error from package pkcs7 to its name in the corpus.
+func errorName(err error) string {
+	switch {
+	case errors.Is(err, pkcs7.ErrInvalidBlockSize):
+		return NameInvalidBlockSize
+	case errors.Is(err, pkcs7.ErrEmptySlice):
+		return NameEmptySlice
+	case errors.Is(err, pkcs7.ErrInvalidLength):
+		return NameInvalidLength
+	case errors.Is(err, pkcs7.ErrInvalidPadding):
+		return NameInvalidPadding
+	}
+	return err.Error()
+}
@@ -0,0 +1,83 @@
+package vectors
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/d1str0/pkcs7"
+)
+
+// TestCorpusInSync fails if pkcs7.json was not regenerated after a change to
+// package pkcs7 or to the generator.
+func TestCorpusInSync(t *testing.T) {
+	corpus, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(corpus, Corpus) {
+		t.Fatal("pkcs7.json is out of date; run go generate in package vectors")
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	f, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := make(map[int]bool)
+	for i, v := range f.Valid {
+		sizes[v.BlockSize] = true
+
+		data, padded := decodeHex(t, v.Data), decodeHex(t, v.Padded)
+		o, err := pkcs7.Pad(data, v.BlockSize)
+		if err != nil || !bytes.Equal(o, padded) {
+			t.Errorf("valid %d: Pad gave %x, %v; expected %x", i, o, err, padded)
+		}
+		o, err = pkcs7.Unpad(padded)
+		if err != nil || !bytes.Equal(o, data) {
+			t.Errorf("valid %d: Unpad gave %x, %v; expected %x", i, o, err, data)
+		}
+	}
+	if len(sizes) != 255 {
+		t.Errorf("expected vectors for 255 block sizes, found %d", len(sizes))
+	}
+
+	names := map[string]error{
+		NameInvalidBlockSize: pkcs7.ErrInvalidBlockSize,
+		NameEmptySlice:       pkcs7.ErrEmptySlice,
+		NameInvalidLength:    pkcs7.ErrInvalidLength,
+		NameInvalidPadding:   pkcs7.ErrInvalidPadding,
+	}
+	for i, v := range f.Invalid {
+		if f.Ops[v.Op] == "" {
+			t.Errorf("invalid %d: op %q is not described", i, v.Op)
+		}
+
+		input := decodeHex(t, v.Input)
+
+		var err error
+		switch v.Op {
+		case OpPad:
+			_, err = pkcs7.Pad(input, v.BlockSize)
+		case OpUnpadBlocks:
+			_, err = pkcs7.PadLen(input, v.BlockSize)
+		default:
+			t.Fatalf("invalid %d: unknown op %q", i, v.Op)
+		}
+		if !errors.Is(err, names[v.Error]) {
+			t.Errorf("invalid %d (%s): expected %s, got %v", i, v.Comment, v.Error, err)
+		}
+	}
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}